@@ -0,0 +1,191 @@
+package main
+
+// This file adds support for taking a ZIP archive as input instead of a bare CSV file.
+// The archive is expected to contain the catalogue CSV plus a folder of images/PDFs named
+// after each box label, e.g. "BOX-042.jpg" or "BOX-042-receipt.pdf". These are attached to
+// the matching box's record(s) so that photos and receipts travel with the catalogue in a
+// single file rather than being managed by hand alongside it.
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// isZipFile reports whether filename looks like a ZIP bundle, based on its extension.
+func isZipFile(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".zip")
+}
+
+// readZipBundle extracts the ZIP archive at zipPath into a temporary directory, locates
+// the single CSV file it contains, parses it as a box catalogue and attaches every other
+// file in the archive named after a box's label (see attachAttachmentsByBoxName) to that
+// box's record(s). The
+// returned cleanup func removes the temporary directory and must be called once the
+// caller is done reading the attachment files (e.g. after copying or uploading them).
+func readZipBundle(zipPath string, categories, locations map[string]bool) (boxCat []BoxCatRecord, issues []ValidationIssue, cleanup func()) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		log.Fatal("Unable to open ZIP bundle "+zipPath, err)
+	}
+	defer r.Close()
+
+	extractDir, err := os.MkdirTemp("", "box-cat-assets-*")
+	if err != nil {
+		log.Fatal("Unable to create temporary directory for ZIP bundle", err)
+	}
+	cleanup = func() {
+		if err := os.RemoveAll(extractDir); err != nil {
+			log.Printf("warning: unable to remove temporary directory %s: %v", extractDir, err)
+		}
+	}
+
+	var csvPath string
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		destPath, err := safeJoin(extractDir, f.Name)
+		if err != nil {
+			cleanup()
+			log.Fatalf("ZIP bundle %s contains an unsafe entry %q: %v\n", zipPath, f.Name, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+			log.Fatal("Unable to create directory for "+destPath, err)
+		}
+		if err := extractZipEntry(f, destPath); err != nil {
+			log.Fatal("Unable to extract "+f.Name+" from ZIP bundle", err)
+		}
+		if strings.EqualFold(filepath.Ext(destPath), ".csv") {
+			if csvPath != "" {
+				log.Fatalf("ZIP bundle %s contains more than one CSV file (%s and %s)\n", zipPath, csvPath, destPath)
+			}
+			csvPath = destPath
+		}
+	}
+	if csvPath == "" {
+		log.Fatalf("ZIP bundle %s does not contain a CSV file\n", zipPath)
+	}
+
+	boxCat, issues = processBoxCatContents(csvPath, categories, locations)
+	attachAttachmentsByBoxName(boxCat, extractDir, csvPath)
+	return boxCat, issues, cleanup
+}
+
+// safeJoin joins dir with a ZIP entry name and guards against Zip Slip: an entry such as
+// "../../etc/passwd" that would resolve outside dir once joined. It returns an error
+// instead of a path if the entry escapes dir.
+func safeJoin(dir, name string) (string, error) {
+	joined := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, joined)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("entry escapes extraction directory")
+	}
+	return joined, nil
+}
+
+func extractZipEntry(f *zip.File, destPath string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// copyAttachmentsAlongsideCSV copies every attachment referenced by snipeData into an
+// "attachments/" directory next to csvOutputPath, and records the relative paths of the
+// copies in each record's Notes column so they can be found after the CSV import.
+func copyAttachmentsAlongsideCSV(csvOutputPath string, snipeData []SnipeITRecord) error {
+	anyAttachments := false
+	for _, data := range snipeData {
+		if len(data.Attachments) > 0 {
+			anyAttachments = true
+			break
+		}
+	}
+	if !anyAttachments {
+		return nil
+	}
+
+	attachmentsDir := filepath.Join(filepath.Dir(csvOutputPath), "attachments")
+	if err := os.MkdirAll(attachmentsDir, 0o755); err != nil {
+		return err
+	}
+
+	for i := range snipeData {
+		var relPaths []string
+		for _, src := range snipeData[i].Attachments {
+			destName := snipeData[i].AssetTag + "-" + filepath.Base(src)
+			destPath := filepath.Join(attachmentsDir, destName)
+			if err := copyFile(src, destPath); err != nil {
+				return err
+			}
+			relPaths = append(relPaths, filepath.Join("attachments", destName))
+		}
+		if len(relPaths) > 0 {
+			if snipeData[i].Notes != "" {
+				snipeData[i].Notes += "; "
+			}
+			snipeData[i].Notes += "Attachments: " + strings.Join(relPaths, ", ")
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// attachAttachmentsByBoxName walks extractDir for files whose name, without its extension,
+// is a box's label or the label followed by "-" (e.g. "BOX-042.jpg" or "BOX-042-receipt.pdf"
+// for box "BOX-042", but not "BOX-0420.jpg") and records their paths in every matching
+// record's Attachments field. The CSV file itself is skipped.
+func attachAttachmentsByBoxName(boxCat []BoxCatRecord, extractDir, csvPath string) {
+	var files []string
+	filepath.Walk(extractDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || p == csvPath {
+			return nil
+		}
+		files = append(files, p)
+		return nil
+	})
+	sort.Strings(files)
+
+	for i := range boxCat {
+		boxName := boxCat[i].BoxName
+		for _, f := range files {
+			base := strings.TrimSuffix(path.Base(filepath.ToSlash(f)), filepath.Ext(f))
+			if base == boxName || strings.HasPrefix(base, boxName+"-") {
+				boxCat[i].Attachments = append(boxCat[i].Attachments, f)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoinRejectsZipSlip(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := []string{
+		"../../../../etc/passwd",
+		"../outside.txt",
+		"a/../../escape.txt",
+	}
+	for _, name := range cases {
+		if _, err := safeJoin(dir, name); err == nil {
+			t.Errorf("safeJoin(%q, %q) = nil error, want rejection of a path escaping dir", dir, name)
+		}
+	}
+}
+
+func TestSafeJoinAllowsOrdinaryEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := []string{"catalogue.csv", "photos/BOX-1.jpg", "a/b/c.pdf"}
+	for _, name := range cases {
+		got, err := safeJoin(dir, name)
+		if err != nil {
+			t.Errorf("safeJoin(%q, %q) returned unexpected error: %v", dir, name, err)
+			continue
+		}
+		want := filepath.Join(dir, filepath.FromSlash(name))
+		if got != want {
+			t.Errorf("safeJoin(%q, %q) = %q, want %q", dir, name, got, want)
+		}
+	}
+}
+
+func TestAttachAttachmentsByBoxName(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "catalogue.csv")
+	for _, name := range []string{"catalogue.csv", "BOX-1.jpg", "BOX-1-receipt.pdf", "BOX-2.jpg", "BOX-10.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("writing fixture %s: %v", name, err)
+		}
+	}
+
+	boxCat := []BoxCatRecord{{BoxName: "BOX-1"}, {BoxName: "BOX-2"}}
+	attachAttachmentsByBoxName(boxCat, dir, csvPath)
+
+	if len(boxCat[0].Attachments) != 2 {
+		t.Errorf("BOX-1 got %d attachments, want 2 (jpg + receipt), got %v", len(boxCat[0].Attachments), boxCat[0].Attachments)
+	}
+	if len(boxCat[1].Attachments) != 1 {
+		t.Errorf("BOX-2 got %d attachments, want 1, got %v", len(boxCat[1].Attachments), boxCat[1].Attachments)
+	}
+	for _, a := range boxCat[0].Attachments {
+		if filepath.Base(a) == "BOX-10.jpg" {
+			t.Errorf("BOX-1's attachments incorrectly include BOX-10.jpg: %v", boxCat[0].Attachments)
+		}
+	}
+}
+
+func TestReadZipBundleExtractsAndCleansUp(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "bundle.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("creating zip fixture: %v", err)
+	}
+	w := zip.NewWriter(f)
+	writeEntry := func(name, contents string) {
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("creating zip entry %s: %v", name, err)
+		}
+		if _, err := entry.Write([]byte(contents)); err != nil {
+			t.Fatalf("writing zip entry %s: %v", name, err)
+		}
+	}
+	writeEntry("catalogue.csv", "Box,Fullness,Sealed,Location,Category,Contents\nBOX-1,Full,No,Shelf A,Cables,HDMI\n")
+	writeEntry("BOX-1.jpg", "fake-image-bytes")
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing zip fixture: %v", err)
+	}
+	f.Close()
+
+	boxCat, _, cleanup := readZipBundle(zipPath, nil, nil)
+	defer cleanup()
+
+	if len(boxCat) != 1 || boxCat[0].BoxName != "BOX-1" {
+		t.Fatalf("readZipBundle boxCat = %+v, want a single BOX-1 record", boxCat)
+	}
+	if len(boxCat[0].Attachments) != 1 {
+		t.Fatalf("readZipBundle attachments = %v, want BOX-1.jpg attached", boxCat[0].Attachments)
+	}
+
+	extractDir := filepath.Dir(boxCat[0].Attachments[0])
+	cleanup()
+	if _, err := os.Stat(extractDir); !os.IsNotExist(err) {
+		t.Fatalf("cleanup did not remove temporary extraction directory %s", extractDir)
+	}
+}
@@ -4,6 +4,38 @@ package main
 // as an input to the Snipe-IT asset management system.
 // It expects the spreadsheet to be converted to a CSV file and takes that CSV file as input.
 //
+// By default (-mode=csv) it writes a CSV file for manual upload via Snipe-IT's importer, but
+// -mode=api pushes each asset straight to a running Snipe-IT server over its REST API instead,
+// via -url/-token/-company-id. BoxName is stored in a custom field whose database column is
+// instance-specific; pass it via -boxname-field if it differs from the default guess.
+//
+// The input file may also be a ZIP bundle (detected by its ".zip" extension) containing the
+// catalogue CSV alongside a folder of images/PDFs named after each box label, e.g. "BOX-042.jpg"
+// or "BOX-042-receipt.pdf". These are attached to the matching box's asset(s): uploaded directly
+// in -mode=api, or copied into a sibling "attachments/" directory next to the output CSV with
+// their paths recorded in the Notes column in -mode=csv.
+//
+// Asset tags are deterministic: by default (-import-ref=hash) they're derived from a hash of
+// each row's stable fields, or with -import-ref=column from a 7th spreadsheet column. Either
+// way the same row always yields the same AssetTag, so re-running the tool against Snipe-IT
+// (which treats AssetTag as unique) updates rather than duplicates. In -mode=api each record
+// is sent as a create request; existing assets are not looked up or updated, and a create the
+// server rejects stops the push. A manifest file records
+// which tags have already been emitted; -since filters to only rows seen at or after a given
+// RFC3339 timestamp, so an incrementally growing spreadsheet can be synced safely.
+//
+// The input need not be a plain UTF-8 CSV file either: -input-format (or the file extension)
+// selects a CatalogueReader, with "xlsx" reading a workbook sheet directly via -sheet and
+// "sheets" fetching a Google Sheet's CSV export given its sheet ID in place of a filename.
+// The default "csv" reader also accepts -delimiter and -charset for CSVs exported with a
+// different field separator or in UTF-16/GBK/Latin-1.
+//
+// Every problem noticed while parsing (a badly formatted verification line, a duplicate
+// BoxName/Contents pair, a Category or Location missing from -categories-file/-locations-file,
+// and so on) is collected as a ValidationIssue rather than just printed. -report writes the
+// full list out as JSON or CSV, and -strict/-fail-on make the process exit non-zero once
+// issues reach a given severity, so this can run as a CI check against the master catalogue.
+//
 // It expects to see data records with items in this order:
 // A box Label, "fullness", "sealed", text indicating a location and finally
 // free form text indicating the item being recorded.
@@ -25,8 +57,8 @@ package main
 // The special processing happens for entries with a "fullness" of "Empty", "Destroyed",
 // "Unassigned", "not printed" and "printed-unused".
 //
-// For any other data line, it is output with the item description, its category, a synthesised tag
-// made up of the box label and the numeric date and time, the location.
+// For any other data line, it is output with the item description, its category, a deterministic
+// tag made up of the box label and an import reference (see -import-ref), the location.
 // Furthermore the  box label is recorded in a custom field "BoxName".
 // The "Model Name" is recorded as "Generic Model"; note that this refers to a Snipe-IT field and
 // not necessarily an attribute of the asset.
@@ -39,17 +71,18 @@ import (
 	"os"
 	"slices"
 	"strings"
-	"time"
 )
 
 // BoxCatRecord is a data structure that reflects the information in the existing catalogue
 type BoxCatRecord struct {
-	BoxName  string
-	Fullness string
-	Sealed   string
-	Location string
-	Category string
-	Contents string
+	BoxName     string
+	Fullness    string
+	Sealed      string
+	Location    string
+	Category    string
+	Contents    string
+	ImportRef   string   // optional stable import reference column, used when -import-ref=column
+	Attachments []string // paths (within an input ZIP bundle) of photos/attachments for this box
 }
 
 // SnipeITRecord is a data structure that holds the information that Snipe-IT needs
@@ -73,8 +106,29 @@ type SnipeITRecord struct {
 	Warranty     string
 	Supplier     string
 	BoxName      string
+	Attachments  []string // paths of photos/attachments for this asset, see Attachments on BoxCatRecord
 }
 
+var (
+	mode           = flag.String("mode", "csv", "output mode: \"csv\" writes a Snipe-IT import CSV, \"api\" pushes straight to a Snipe-IT server")
+	snipeitURL     = flag.String("url", "", "Snipe-IT base URL, e.g. https://assets.example.com (required for -mode=api)")
+	token          = flag.String("token", "", "Snipe-IT API bearer token (required for -mode=api)")
+	companyID      = flag.String("company-id", "", "Snipe-IT company ID to set on created assets (optional, -mode=api only)")
+	boxNameField   = flag.String("boxname-field", "", "Snipe-IT custom field database column to store BoxName in, e.g. \"_snipeit_boxname_3\" (default: \""+defaultBoxNameField+"\"; the numeric suffix is assigned per-instance, so check /api/v1/fields on your server; -mode=api only)")
+	importRef      = flag.String("import-ref", importRefHash, "asset tag strategy: \""+importRefHash+"\" derives a stable tag from BoxName|Category|Contents|Location, \""+importRefColumn+"\" uses a 7th \"ImportRef\" spreadsheet column")
+	since          = flag.String("since", "", "RFC3339 timestamp: only emit rows first seen in the manifest at or after this time")
+	manifestFlag   = flag.String("manifest", "", "path to the manifest file tracking previously emitted AssetTags (default: \"<output>.manifest.json\" in -mode=csv, disabled otherwise)")
+	inputFormat    = flag.String("input-format", "", "input format: \""+inputFormatCSV+"\", \""+inputFormatXLSX+"\" or \""+inputFormatSheets+"\" (default: inferred from the input file's extension)")
+	delimiter      = flag.String("delimiter", "", "CSV field delimiter (default: comma; -input-format=csv only)")
+	charset        = flag.String("charset", "", "input charset: utf-8, utf-16, gbk or latin1 (default: utf-8; -input-format=csv only)")
+	sheetName      = flag.String("sheet", "", "workbook sheet to read (default: the first sheet; -input-format=xlsx only)")
+	report         = flag.String("report", "", "path to write a validation report to (JSON, or CSV if the path ends in .csv)")
+	strict         = flag.Bool("strict", false, "exit non-zero if any Warn-or-worse validation issue is found (equivalent to -fail-on=warn)")
+	failOn         = flag.String("fail-on", "", "exit non-zero if any issue at or above this severity (info, warn, error) is found")
+	categoriesFile = flag.String("categories-file", "", "path to an allowlist file (one value per line) of valid Category values")
+	locationsFile  = flag.String("locations-file", "", "path to an allowlist file (one value per line) of valid Location values")
+)
+
 func main() {
 
 	flag.Parse()
@@ -85,13 +139,78 @@ func main() {
 	}
 
 	boxCatFilename := flag.Arg(0)
-	outputFile := flag.Arg(1)
+	outputArg := flag.Arg(1)
+
+	categories := loadAllowlist(*categoriesFile)
+	locations := loadAllowlist(*locationsFile)
+
+	var boxCat []BoxCatRecord
+	var issues []ValidationIssue
+	if *inputFormat == "" && isZipFile(boxCatFilename) {
+		var cleanup func()
+		boxCat, issues, cleanup = readZipBundle(boxCatFilename, categories, locations)
+		defer cleanup()
+	} else {
+		reader, err := newCatalogueReader(boxCatFilename, *inputFormat, *delimiter, *charset, *sheetName, categories, locations)
+		if err != nil {
+			log.Fatal(err)
+		}
+		boxCat, issues, err = reader.Read()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *report != "" {
+		if err := WriteValidationReport(*report, issues); err != nil {
+			log.Fatalf("writing validation report to %s failed: %v\n", *report, err)
+		}
+	}
+
+	failThreshold := Error + 1 // never fails
+	if *strict {
+		failThreshold = Warn
+	}
+	if *failOn != "" {
+		var err error
+		failThreshold, err = parseSeverity(*failOn)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	boxCat := processBoxCatContents(boxCatFilename)
+	snipeData := BuildSnipeITContents(boxCat, *importRef)
 
-	snipeData := BuildSnipeITContents(boxCat)
+	manifestPath := *manifestFlag
+	if manifestPath == "" && *mode == "csv" {
+		manifestPath = defaultManifestPath(outputArg)
+	}
+	manifestData := loadManifest(manifestPath)
+	snipeData = applyManifest(snipeData, manifestData, *since)
+	saveManifest(manifestPath, manifestData)
 
-	WriteSnipeITCSV(outputFile, snipeData)
+	switch *mode {
+	case "csv":
+		if err := copyAttachmentsAlongsideCSV(outputArg, snipeData); err != nil {
+			log.Fatalf("copying attachments alongside %s failed: %v\n", outputArg, err)
+		}
+		WriteSnipeITCSV(outputArg, snipeData)
+	case "api":
+		if *snipeitURL == "" || *token == "" {
+			log.Fatal("-mode=api requires both -url and -token")
+		}
+		client := newSnipeITClient(*snipeitURL, *token, *companyID, *boxNameField)
+		if err := client.PushSnipeITContents(snipeData); err != nil {
+			log.Fatalf("pushing to Snipe-IT failed: %v\n", err)
+		}
+	default:
+		log.Fatalf("Unknown -mode %q: expected \"csv\" or \"api\"\n", *mode)
+	}
+
+	if worstSeverity(issues) >= failThreshold {
+		fmt.Printf("%d validation issue(s) found, worst severity %s; failing due to -strict/-fail-on\n", len(issues), worstSeverity(issues))
+		os.Exit(1)
+	}
 }
 
 func WriteSnipeITCSV(filename string, snipeData []SnipeITRecord) {
@@ -135,14 +254,11 @@ func WriteSnipeITCSV(filename string, snipeData []SnipeITRecord) {
 	}
 }
 
-func BuildSnipeITContents(boxCatrecords []BoxCatRecord) []SnipeITRecord {
+func BuildSnipeITContents(boxCatrecords []BoxCatRecord, importRefStrategy string) []SnipeITRecord {
 	var snipeITrecords []SnipeITRecord
-	for index, entry := range boxCatrecords {
+	for _, entry := range boxCatrecords {
 		var data SnipeITRecord
 
-		currentTime := time.Now()
-		tag := fmt.Sprintf("%4d%02d%02d%02d%02d%02d-%08d", currentTime.Year(), currentTime.Month, currentTime.Day(), currentTime.Hour(), currentTime.Minute(), currentTime.Second(), index)
-
 		data.FullName = ""
 		data.Email = ""
 		data.Username = ""
@@ -152,7 +268,7 @@ func BuildSnipeITContents(boxCatrecords []BoxCatRecord) []SnipeITRecord {
 		data.Manufacturer = ""
 		data.ModelNumber = ""
 		data.SerialNumber = ""
-		data.AssetTag = entry.BoxName + "-" + tag
+		data.AssetTag = computeAssetTag(entry, importRefStrategy)
 		data.Location = entry.Location
 		data.Notes = ""
 		data.PurchaseDate = ""
@@ -162,6 +278,7 @@ func BuildSnipeITContents(boxCatrecords []BoxCatRecord) []SnipeITRecord {
 		data.Warranty = ""
 		data.Supplier = ""
 		data.BoxName = entry.BoxName
+		data.Attachments = entry.Attachments
 
 		snipeITrecords = append(snipeITrecords, data)
 	}
@@ -169,10 +286,23 @@ func BuildSnipeITContents(boxCatrecords []BoxCatRecord) []SnipeITRecord {
 	return snipeITrecords
 }
 
-func processBoxCatContents(filename string) []BoxCatRecord {
+// processBoxCatContents reads a local CSV file via readCsvFile and parses it. It remains as
+// a convenience for callers (such as the ZIP bundle reader) that already have a plain UTF-8,
+// comma-separated CSV file in hand; it does not go through localCSVReader, so -delimiter and
+// -charset do not apply to it.
+func processBoxCatContents(filename string, categories, locations map[string]bool) ([]BoxCatRecord, []ValidationIssue) {
 	records := readCsvFile(filename)
+	return parseBoxCatRows(records, categories, locations)
+}
 
+// parseBoxCatRows interprets raw spreadsheet rows, common to every CatalogueReader, into
+// BoxCatRecords, alongside a ValidationIssue for every problem noticed along the way.
+// categories and locations are optional allowlists (nil disables the corresponding check);
+// see -categories-file and -locations-file.
+func parseBoxCatRows(records [][]string, categories, locations map[string]bool) ([]BoxCatRecord, []ValidationIssue) {
 	var boxcat []BoxCatRecord
+	var issues []ValidationIssue
+	seenBoxContents := make(map[string]int) // "BoxName|Contents" -> row first seen
 	var skipHeaders bool = true
 	for index, entry := range records {
 		if skipHeaders {
@@ -193,7 +323,7 @@ func processBoxCatContents(filename string) []BoxCatRecord {
 			prefix_len := len(verif_prefix)
 			expected_data := "V" + entry[0][prefix_len:]
 			if (entry[1] != expected_data) || (entry[2] != expected_data) || (entry[3] != expected_data) || (entry[4] != expected_data) || (entry[5] != expected_data) {
-				fmt.Println("Badly formatted verification line:", entry)
+				addIssue(&issues, index, entry[0], Warn, "bad_verification_format", fmt.Sprint("Badly formatted verification line: ", entry))
 			}
 			continue
 		}
@@ -205,30 +335,30 @@ func processBoxCatContents(filename string) []BoxCatRecord {
 			case "empty":
 				// An empty box can specify a location
 				if len(entry[5]) > 0 {
-					fmt.Println("Empty box with data at", index, entry)
+					addIssue(&issues, index, entry[0], Warn, "empty_box_with_data", fmt.Sprint("Empty box with data at ", index, " ", entry))
 				}
 			case "destroyed":
 				if (len(entry[2]) > 0) || (len(entry[3]) > 0) || (len(entry[4]) > 0) || (len(entry[5]) > 0) {
-					fmt.Println("Destroyed box with data at", index, entry)
+					addIssue(&issues, index, entry[0], Warn, "destroyed_box_with_data", fmt.Sprint("Destroyed box with data at ", index, " ", entry))
 				}
 			case "unassigned":
 				if (len(entry[2]) > 0) || (len(entry[3]) > 0) || (len(entry[4]) > 0) || (len(entry[5]) > 0) {
-					fmt.Println("Unassigned box with data at", index, entry)
+					addIssue(&issues, index, entry[0], Warn, "unassigned_box_with_data", fmt.Sprint("Unassigned box with data at ", index, " ", entry))
 				}
 			case "not printed":
 				if (len(entry[2]) > 0) || (len(entry[3]) > 0) || (len(entry[4]) > 0) || (len(entry[5]) > 0) {
-					fmt.Println("Unprinted box label with data at", index, entry)
+					addIssue(&issues, index, entry[0], Warn, "unprinted_box_with_data", fmt.Sprint("Unprinted box label with data at ", index, " ", entry))
 				}
 			case "printed-unused":
 				if (len(entry[2]) > 0) || (len(entry[3]) > 0) || (len(entry[4]) > 0) || (len(entry[5]) > 0) {
-					fmt.Println("Unused box label with data at", index, entry)
+					addIssue(&issues, index, entry[0], Warn, "printed_unused_box_with_data", fmt.Sprint("Unused box label with data at ", index, " ", entry))
 				}
 			default:
-				fmt.Println("Unhandled fullness stat: at", index, entry, "[", fullness, "]")
+				addIssue(&issues, index, entry[0], Error, "unknown_fullness", fmt.Sprint("Unhandled fullness stat: at ", index, " ", entry, " [", fullness, "]"))
 			}
 			continue
 		} else if len(entry[5]) == 0 {
-			fmt.Println("Unhandled no data stat: at", index, entry)
+			addIssue(&issues, index, entry[0], Warn, "no_data", fmt.Sprint("Unhandled no data stat: at ", index, " ", entry))
 			continue
 		}
 
@@ -240,10 +370,27 @@ func processBoxCatContents(filename string) []BoxCatRecord {
 		data.Location = entry[3]
 		data.Category = entry[4]
 		data.Contents = entry[5]
+		if len(entry) > 6 {
+			data.ImportRef = strings.TrimSpace(entry[6])
+		}
+
+		boxContentsKey := data.BoxName + "|" + data.Contents
+		if firstRow, seen := seenBoxContents[boxContentsKey]; seen {
+			addIssue(&issues, index, data.BoxName, Warn, "duplicate_box_contents", fmt.Sprintf("Duplicate BoxName/Contents pair at row %d, box %q: already seen at row %d", index, data.BoxName, firstRow))
+		} else {
+			seenBoxContents[boxContentsKey] = index
+		}
+
+		if categories != nil && !categories[data.Category] {
+			addIssue(&issues, index, data.BoxName, Error, "unknown_category", fmt.Sprintf("Unknown category %q at row %d, box %q", data.Category, index, data.BoxName))
+		}
+		if locations != nil && !locations[data.Location] {
+			addIssue(&issues, index, data.BoxName, Error, "unknown_location", fmt.Sprintf("Unknown location %q at row %d, box %q", data.Location, index, data.BoxName))
+		}
 
 		boxcat = append(boxcat, data)
 	}
-	return boxcat
+	return boxcat, issues
 }
 
 func readCsvFile(filePath string) [][]string {
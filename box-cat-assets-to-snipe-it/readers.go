@@ -0,0 +1,201 @@
+package main
+
+// This file lets the catalogue be read from more than a plain UTF-8 CSV file: users kept
+// getting tripped up exporting their spreadsheet to CSV by hand, often from a Windows
+// machine producing UTF-16 or GBK-encoded files, or from Google Sheets which can be read
+// directly without exporting at all. A CatalogueReader abstracts over where the rows come
+// from; parseBoxCatRows (in box-cat-assets-to-snipe-it.go) still does the actual row
+// interpretation so every reader behaves identically once it has produced [][]string rows.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const (
+	inputFormatCSV    = "csv"
+	inputFormatXLSX   = "xlsx"
+	inputFormatSheets = "sheets"
+)
+
+// CatalogueReader produces the catalogue as a slice of BoxCatRecord, alongside any
+// ValidationIssues noticed while parsing it, regardless of where or in what format the
+// underlying data is stored.
+type CatalogueReader interface {
+	Read() ([]BoxCatRecord, []ValidationIssue, error)
+}
+
+// newCatalogueReader picks a CatalogueReader for filename. format may be empty, in which
+// case it is inferred from filename's extension (falling back to CSV); delimiter and
+// charset only apply to the CSV reader, and sheet only to the XLSX reader. categories and
+// locations are the optional allowlists described under -categories-file/-locations-file.
+func newCatalogueReader(filename, format, delimiter, charset, sheet string, categories, locations map[string]bool) (CatalogueReader, error) {
+	if format == "" {
+		format = inputFormatFromExtension(filename)
+	}
+
+	switch format {
+	case inputFormatCSV:
+		return &localCSVReader{path: filename, delimiter: delimiter, charset: charset, categories: categories, locations: locations}, nil
+	case inputFormatXLSX:
+		return &xlsxReader{path: filename, sheet: sheet, categories: categories, locations: locations}, nil
+	case inputFormatSheets:
+		return &googleSheetsReader{sheetID: filename, categories: categories, locations: locations}, nil
+	default:
+		return nil, fmt.Errorf("unknown -input-format %q: expected %q, %q or %q", format, inputFormatCSV, inputFormatXLSX, inputFormatSheets)
+	}
+}
+
+func inputFormatFromExtension(filename string) string {
+	switch {
+	case strings.HasSuffix(strings.ToLower(filename), ".xlsx"):
+		return inputFormatXLSX
+	default:
+		return inputFormatCSV
+	}
+}
+
+// localCSVReader reads a local CSV file, decoding it from the charset named by -charset
+// (UTF-8 when none is given; the charset is not detected from the content) and applying a
+// configurable field delimiter before handing rows to parseBoxCatRows.
+type localCSVReader struct {
+	path       string
+	delimiter  string
+	charset    string
+	categories map[string]bool
+	locations  map[string]bool
+}
+
+func (r *localCSVReader) Read() ([]BoxCatRecord, []ValidationIssue, error) {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read input file %s: %w", r.path, err)
+	}
+	defer f.Close()
+
+	decoded, err := decodeCharset(f, r.charset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	csvReader := csv.NewReader(decoded)
+	if r.delimiter != "" {
+		runes := []rune(r.delimiter)
+		if len(runes) != 1 {
+			return nil, nil, fmt.Errorf("-delimiter must be a single character, got %q", r.delimiter)
+		}
+		csvReader.Comma = runes[0]
+	}
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse file as CSV for %s: %w", r.path, err)
+	}
+	boxcat, issues := parseBoxCatRows(records, r.categories, r.locations)
+	return boxcat, issues, nil
+}
+
+// decodeCharset wraps r so that it yields UTF-8, decoding from the named charset first.
+// An empty or "utf-8" charset is passed through unchanged.
+func decodeCharset(r io.Reader, charset string) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8":
+		return r, nil
+	case "utf-16", "utf16":
+		return transform.NewReader(r, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()), nil
+	case "gbk":
+		return transform.NewReader(r, simplifiedchinese.GBK.NewDecoder()), nil
+	case "latin1", "iso-8859-1":
+		return transform.NewReader(r, charmap.ISO8859_1.NewDecoder()), nil
+	default:
+		return nil, fmt.Errorf("unknown -charset %q: expected utf-8, utf-16, gbk or latin1", charset)
+	}
+}
+
+// xlsxReader reads a named sheet of a local .xlsx workbook directly, so that users don't
+// have to export their spreadsheet to CSV before running this tool.
+type xlsxReader struct {
+	path       string
+	sheet      string
+	categories map[string]bool
+	locations  map[string]bool
+}
+
+func (r *xlsxReader) Read() ([]BoxCatRecord, []ValidationIssue, error) {
+	f, err := excelize.OpenFile(r.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to open workbook %s: %w", r.path, err)
+	}
+	defer f.Close()
+
+	sheet := r.sheet
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read sheet %q of %s: %w", sheet, r.path, err)
+	}
+	boxcat, issues := parseBoxCatRows(padRows(rows, minBoxCatRowWidth), r.categories, r.locations)
+	return boxcat, issues, nil
+}
+
+// minBoxCatRowWidth is the number of fields parseBoxCatRows indexes unconditionally
+// (Box, Fullness, Sealed, Location, Category, Contents).
+const minBoxCatRowWidth = 6
+
+// padRows pads every row to at least width fields with empty strings. Unlike encoding/csv,
+// excelize.GetRows trims trailing blank cells, so a row whose last cells (e.g. Category and
+// Contents) are empty comes back shorter than the rest; parseBoxCatRows assumes a fixed
+// minimum width.
+func padRows(rows [][]string, width int) [][]string {
+	padded := make([][]string, len(rows))
+	for i, row := range rows {
+		if len(row) >= width {
+			padded[i] = row
+			continue
+		}
+		padded[i] = append(append([]string{}, row...), make([]string, width-len(row))...)
+	}
+	return padded
+}
+
+// googleSheetsReader fetches a published Google Sheet's CSV export directly, given its
+// sheet ID, so that the catalogue can live in Google Sheets without a manual export step.
+type googleSheetsReader struct {
+	sheetID    string
+	categories map[string]bool
+	locations  map[string]bool
+}
+
+func (r *googleSheetsReader) Read() ([]BoxCatRecord, []ValidationIssue, error) {
+	exportURL := fmt.Sprintf("https://docs.google.com/spreadsheets/d/%s/export?format=csv", r.sheetID)
+
+	resp, err := http.Get(exportURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to download Google Sheet %s: %w", r.sheetID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unable to download Google Sheet %s: %s", r.sheetID, resp.Status)
+	}
+
+	csvReader := csv.NewReader(resp.Body)
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse Google Sheet %s as CSV: %w", r.sheetID, err)
+	}
+	boxcat, issues := parseBoxCatRows(records, r.categories, r.locations)
+	return boxcat, issues, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestInputFormatFromExtension(t *testing.T) {
+	cases := map[string]string{
+		"catalogue.csv":  inputFormatCSV,
+		"catalogue.xlsx": inputFormatXLSX,
+		"catalogue.XLSX": inputFormatXLSX,
+		"catalogue":      inputFormatCSV,
+	}
+	for filename, want := range cases {
+		if got := inputFormatFromExtension(filename); got != want {
+			t.Errorf("inputFormatFromExtension(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestNewCatalogueReaderDispatch(t *testing.T) {
+	r, err := newCatalogueReader("catalogue.xlsx", "", "", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("newCatalogueReader returned error: %v", err)
+	}
+	if _, ok := r.(*xlsxReader); !ok {
+		t.Errorf("newCatalogueReader(catalogue.xlsx) = %T, want *xlsxReader", r)
+	}
+
+	r, err = newCatalogueReader("sheet-id", inputFormatSheets, "", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("newCatalogueReader returned error: %v", err)
+	}
+	if _, ok := r.(*googleSheetsReader); !ok {
+		t.Errorf("newCatalogueReader(-input-format=sheets) = %T, want *googleSheetsReader", r)
+	}
+
+	if _, err := newCatalogueReader("catalogue.csv", "bogus", "", "", "", nil, nil); err == nil {
+		t.Error("newCatalogueReader with an unknown -input-format did not return an error")
+	}
+}
+
+func TestDecodeCharsetUTF8PassesThrough(t *testing.T) {
+	r, err := decodeCharset(strings.NewReader("hello"), "")
+	if err != nil {
+		t.Fatalf("decodeCharset returned error: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading decoded reader: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("decodeCharset(\"\") = %q, want passthrough %q", got, "hello")
+	}
+}
+
+func TestDecodeCharsetUnknown(t *testing.T) {
+	if _, err := decodeCharset(strings.NewReader(""), "ebcdic"); err == nil {
+		t.Error("decodeCharset with an unknown charset did not return an error")
+	}
+}
+
+func TestPadRows(t *testing.T) {
+	rows := [][]string{
+		{"BOX-1", "Full"},
+		{"BOX-2", "Empty", "No", "Shelf A", "Cables", "HDMI"},
+	}
+	padded := padRows(rows, minBoxCatRowWidth)
+
+	if len(padded[0]) != minBoxCatRowWidth {
+		t.Fatalf("padRows short row length = %d, want %d", len(padded[0]), minBoxCatRowWidth)
+	}
+	if padded[0][0] != "BOX-1" || padded[0][1] != "Full" || padded[0][2] != "" {
+		t.Errorf("padRows short row = %v, want original values preserved then padded with empties", padded[0])
+	}
+	if len(padded[1]) != minBoxCatRowWidth {
+		t.Errorf("padRows must not truncate a row already at width, got %v", padded[1])
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+// This file implements "-mode=api" which pushes SnipeITRecord entries straight into a
+// running Snipe-IT server over its REST API, instead of writing them out as a CSV for
+// manual upload. It looks up (and lazily creates) the Locations/Categories/Manufacturers/
+// Models that each record refers to, caching the IDs so that a catalogue with many rows
+// referring to the same location or category doesn't create duplicates server-side.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// snipeITClient talks to a Snipe-IT server and caches the IDs of named resources so that
+// repeated lookups of the same Location/Category/Manufacturer/Model name don't round-trip.
+type snipeITClient struct {
+	baseURL      string
+	token        string
+	companyID    string
+	boxNameField string
+	http         *http.Client
+
+	locationIDs     map[string]int
+	categoryIDs     map[string]int
+	manufacturerIDs map[string]int
+	modelIDs        map[string]int
+}
+
+// defaultBoxNameField is a reasonable guess at the custom field's database column, but the
+// numeric suffix is assigned by each Snipe-IT instance when the custom field is created, so
+// it must be overridable via -boxname-field rather than assumed.
+const defaultBoxNameField = "_snipeit_boxname_1"
+
+func newSnipeITClient(baseURL, token, companyID, boxNameField string) *snipeITClient {
+	if boxNameField == "" {
+		boxNameField = defaultBoxNameField
+	}
+	return &snipeITClient{
+		baseURL:         baseURL,
+		token:           token,
+		companyID:       companyID,
+		boxNameField:    boxNameField,
+		http:            &http.Client{Timeout: 30 * time.Second},
+		locationIDs:     make(map[string]int),
+		categoryIDs:     make(map[string]int),
+		manufacturerIDs: make(map[string]int),
+		modelIDs:        make(map[string]int),
+	}
+}
+
+// PushSnipeITContents POSTs every record to the Snipe-IT Hardware/Assets API, creating
+// any Locations/Categories/Manufacturers/Models it has not seen before. It stops at the
+// first record that fails after retries and returns the error so the caller can log.Fatal.
+func (c *snipeITClient) PushSnipeITContents(snipeData []SnipeITRecord) error {
+	for _, data := range snipeData {
+		if err := c.pushOne(data); err != nil {
+			return fmt.Errorf("pushing asset tag %q: %w", data.AssetTag, err)
+		}
+	}
+	return nil
+}
+
+func (c *snipeITClient) pushOne(data SnipeITRecord) error {
+	locationID, err := c.lookupOrCreate(c.locationIDs, data.Location, "/api/v1/locations")
+	if err != nil {
+		return err
+	}
+	categoryID, err := c.lookupOrCreate(c.categoryIDs, data.Category, "/api/v1/categories")
+	if err != nil {
+		return err
+	}
+	manufacturerID := 0
+	if data.Manufacturer != "" {
+		manufacturerID, err = c.lookupOrCreate(c.manufacturerIDs, data.Manufacturer, "/api/v1/manufacturers")
+		if err != nil {
+			return err
+		}
+	}
+	modelID, err := c.lookupOrCreateModel(data.ModelName, categoryID, manufacturerID)
+	if err != nil {
+		return err
+	}
+
+	asset := map[string]any{
+		"asset_tag": data.AssetTag,
+		"status_id": 1,
+		"model_id":  modelID,
+		"name":      data.ItemName,
+		"notes":     data.Notes,
+		"serial":    data.SerialNumber,
+	}
+	if c.boxNameField != "" {
+		asset[c.boxNameField] = data.BoxName
+	}
+	if locationID != 0 {
+		asset["rtd_location_id"] = locationID
+	}
+	if c.companyID != "" {
+		asset["company_id"] = c.companyID
+	}
+
+	body, err := c.doWithRetry(http.MethodPost, "/api/v1/hardware", asset)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Status  string `json:"status"`
+		Payload struct {
+			ID int `json:"id"`
+		} `json:"payload"`
+		Messages any `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &result); err == nil && result.Status == "error" {
+		return fmt.Errorf("snipe-it rejected asset: %v", result.Messages)
+	}
+
+	for _, attachment := range data.Attachments {
+		if err := c.uploadAttachment(result.Payload.ID, attachment); err != nil {
+			return fmt.Errorf("uploading attachment %q: %w", attachment, err)
+		}
+	}
+	return nil
+}
+
+// uploadAttachment sends a single photo/PDF to Snipe-IT's per-asset file upload endpoint.
+func (c *snipeITClient) uploadAttachment(assetID int, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file[]", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxSnipeITRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(math.Pow(2, float64(attempt))) * time.Second
+			log.Printf("snipe-it api: retrying attachment upload for asset %d after %v (attempt %d)", assetID, backoff, attempt+1)
+			time.Sleep(backoff)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/v1/hardware/%d/upload", c.baseURL, assetID), bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("upload: %s: %s", resp.Status, respBody)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("upload: %s: %s", resp.Status, respBody)
+		}
+		return nil
+	}
+	return fmt.Errorf("giving up uploading attachment after %d attempts: %w", maxSnipeITRetries, lastErr)
+}
+
+// lookupOrCreate finds the ID of a named resource (location/category/manufacturer) via
+// cache first, then searches the server, and creates it if it still isn't found.
+func (c *snipeITClient) lookupOrCreate(cache map[string]int, name, endpoint string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+	if id, ok := cache[name]; ok {
+		return id, nil
+	}
+
+	body, err := c.doWithRetry(http.MethodGet, endpoint+"?search="+url.QueryEscape(name), nil)
+	if err != nil {
+		return 0, err
+	}
+	var listResult struct {
+		Rows []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"rows"`
+	}
+	if err := json.Unmarshal(body, &listResult); err != nil {
+		return 0, fmt.Errorf("decoding %s search: %w", endpoint, err)
+	}
+	for _, row := range listResult.Rows {
+		if row.Name == name {
+			cache[name] = row.ID
+			return row.ID, nil
+		}
+	}
+
+	created, err := c.doWithRetry(http.MethodPost, endpoint, map[string]any{"name": name})
+	if err != nil {
+		return 0, err
+	}
+	var createResult struct {
+		Payload struct {
+			ID int `json:"id"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(created, &createResult); err != nil {
+		return 0, fmt.Errorf("decoding %s create: %w", endpoint, err)
+	}
+	cache[name] = createResult.Payload.ID
+	return createResult.Payload.ID, nil
+}
+
+func (c *snipeITClient) lookupOrCreateModel(name string, categoryID, manufacturerID int) (int, error) {
+	key := name + "|" + strconv.Itoa(categoryID) + "|" + strconv.Itoa(manufacturerID)
+	if id, ok := c.modelIDs[key]; ok {
+		return id, nil
+	}
+
+	body, err := c.doWithRetry(http.MethodGet, "/api/v1/models?search="+url.QueryEscape(name), nil)
+	if err != nil {
+		return 0, err
+	}
+	var listResult struct {
+		Rows []struct {
+			ID             int    `json:"id"`
+			Name           string `json:"name"`
+			CategoryID     int    `json:"category_id"`
+			ManufacturerID int    `json:"manufacturer_id"`
+		} `json:"rows"`
+	}
+	if err := json.Unmarshal(body, &listResult); err != nil {
+		return 0, fmt.Errorf("decoding model search: %w", err)
+	}
+	for _, row := range listResult.Rows {
+		if row.Name == name && row.CategoryID == categoryID && row.ManufacturerID == manufacturerID {
+			c.modelIDs[key] = row.ID
+			return row.ID, nil
+		}
+	}
+
+	create := map[string]any{"name": name, "category_id": categoryID}
+	if manufacturerID != 0 {
+		create["manufacturer_id"] = manufacturerID
+	}
+	created, err := c.doWithRetry(http.MethodPost, "/api/v1/models", create)
+	if err != nil {
+		return 0, err
+	}
+	var createResult struct {
+		Payload struct {
+			ID int `json:"id"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(created, &createResult); err != nil {
+		return 0, fmt.Errorf("decoding model create: %w", err)
+	}
+	c.modelIDs[key] = createResult.Payload.ID
+	return createResult.Payload.ID, nil
+}
+
+const maxSnipeITRetries = 5
+
+// doWithRetry issues a single request, retrying with exponential backoff on HTTP 429 and
+// 5xx responses. A nil payload sends a GET/no body request.
+func (c *snipeITClient) doWithRetry(method, path string, payload any) ([]byte, error) {
+	var bodyBytes []byte
+	if payload != nil {
+		var err error
+		bodyBytes, err = json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxSnipeITRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(math.Pow(2, float64(attempt))) * time.Second
+			log.Printf("snipe-it api: retrying %s %s after %v (attempt %d)", method, path, backoff, attempt+1)
+			time.Sleep(backoff)
+		}
+
+		req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		req.Header.Set("Accept", "application/json")
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("%s %s: %s", method, path, resp.Status)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, respBody)
+		}
+		return respBody, nil
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", maxSnipeITRetries, lastErr)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *snipeITClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return newSnipeITClient(server.URL, "test-token", "", "")
+}
+
+func TestLookupOrCreateFindsExisting(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Fatalf("expected a GET search, got %s", r.Method)
+		}
+		if got := r.URL.Query().Get("search"); got != "Server Room & Storage" {
+			t.Fatalf("search query = %q, want escaped-then-decoded %q", got, "Server Room & Storage")
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"rows": []map[string]any{{"id": 7, "name": "Server Room & Storage"}},
+		})
+	})
+
+	id, err := client.lookupOrCreate(client.locationIDs, "Server Room & Storage", "/api/v1/locations")
+	if err != nil {
+		t.Fatalf("lookupOrCreate returned error: %v", err)
+	}
+	if id != 7 {
+		t.Fatalf("lookupOrCreate = %d, want 7", id)
+	}
+	if cached := client.locationIDs["Server Room & Storage"]; cached != 7 {
+		t.Fatalf("lookupOrCreate did not cache the ID, got %d", cached)
+	}
+}
+
+func TestLookupOrCreateCreatesWhenMissing(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(map[string]any{"rows": []map[string]any{}})
+		case http.MethodPost:
+			json.NewEncoder(w).Encode(map[string]any{"payload": map[string]any{"id": 42}})
+		default:
+			t.Fatalf("unexpected method %s", r.Method)
+		}
+	})
+
+	id, err := client.lookupOrCreate(client.categoryIDs, "New Category", "/api/v1/categories")
+	if err != nil {
+		t.Fatalf("lookupOrCreate returned error: %v", err)
+	}
+	if id != 42 {
+		t.Fatalf("lookupOrCreate = %d, want 42", id)
+	}
+}
+
+func TestLookupOrCreateModelFiltersByCategory(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(map[string]any{
+				"rows": []map[string]any{{"id": 1, "name": "Generic-Model", "category_id": 5, "manufacturer_id": 0}},
+			})
+		case http.MethodPost:
+			json.NewEncoder(w).Encode(map[string]any{"payload": map[string]any{"id": 99}})
+		default:
+			t.Fatalf("unexpected method %s", r.Method)
+		}
+	})
+
+	// A same-named model exists, but tied to a different category (5); since we're
+	// asking for category 6, lookupOrCreateModel must create a new model rather than
+	// reusing the ID belonging to category 5.
+	id, err := client.lookupOrCreateModel("Generic-Model", 6, 0)
+	if err != nil {
+		t.Fatalf("lookupOrCreateModel returned error: %v", err)
+	}
+	if id != 99 {
+		t.Fatalf("lookupOrCreateModel = %d, want 99 (a newly created model), not the category-5 model's ID", id)
+	}
+}
+
+func TestLookupOrCreateModelReusesSameCategory(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Fatalf("expected a GET search, no POST create was needed, got %s", r.Method)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"rows": []map[string]any{{"id": 1, "name": "Generic-Model", "category_id": 5, "manufacturer_id": 0}},
+		})
+	})
+
+	id, err := client.lookupOrCreateModel("Generic-Model", 5, 0)
+	if err != nil {
+		t.Fatalf("lookupOrCreateModel returned error: %v", err)
+	}
+	if id != 1 {
+		t.Fatalf("lookupOrCreateModel = %d, want 1 (the existing category-5 model)", id)
+	}
+}
+
+func TestDoWithRetryRecoversFrom5xx(t *testing.T) {
+	attempts := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+
+	body, err := client.doWithRetry(http.MethodGet, "/api/v1/hardware", nil)
+	if err != nil {
+		t.Fatalf("doWithRetry returned error after a retryable 503: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("server saw %d attempts, want 2 (one 503 then a success)", attempts)
+	}
+	if string(body) != `{"status":"ok"}` {
+		t.Fatalf("doWithRetry body = %q", body)
+	}
+}
+
+func TestDoWithRetryDoesNotRetry4xx(t *testing.T) {
+	attempts := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	if _, err := client.doWithRetry(http.MethodGet, "/api/v1/hardware", nil); err == nil {
+		t.Fatal("doWithRetry did not return an error for a 400 response")
+	}
+	if attempts != 1 {
+		t.Fatalf("server saw %d attempts, want 1 (4xx should not be retried)", attempts)
+	}
+}
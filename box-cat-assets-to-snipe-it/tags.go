@@ -0,0 +1,132 @@
+package main
+
+// This file makes asset tags deterministic and idempotent. Previously AssetTag was built
+// from the current time, so re-running the tool on the same spreadsheet produced brand new
+// tags and Snipe-IT (which treats AssetTag as unique) ended up with duplicate assets. Tags
+// are now derived either from an explicit "import reference" column in the spreadsheet or
+// from a hash of the row's stable fields, and an on-disk manifest remembers when each tag was
+// first emitted so that, with -since, repeated syncs of an incrementally growing spreadsheet
+// emit only rows first seen at or after that time. Without -since every row is emitted again,
+// under the same tag as before.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	importRefColumn = "column"
+	importRefHash   = "hash"
+)
+
+// computeAssetTag derives a stable AssetTag for entry according to strategy. "column" uses
+// the ImportRef value read from the spreadsheet directly; "hash" derives one from the box's
+// stable fields so that the same row always yields the same tag even without a dedicated
+// import-ref column.
+func computeAssetTag(entry BoxCatRecord, strategy string) string {
+	switch strategy {
+	case importRefColumn:
+		if entry.ImportRef == "" {
+			log.Fatalf("Row for box %q has no import-ref value but -import-ref=%s was requested\n", entry.BoxName, importRefColumn)
+		}
+		return entry.BoxName + "-" + entry.ImportRef
+	case importRefHash:
+		sum := sha256.Sum256([]byte(entry.BoxName + "|" + entry.Category + "|" + entry.Contents + "|" + entry.Location))
+		return entry.BoxName + "-" + hex.EncodeToString(sum[:])[:12]
+	default:
+		log.Fatalf("Unknown -import-ref strategy %q: expected %q or %q\n", strategy, importRefColumn, importRefHash)
+		return ""
+	}
+}
+
+// manifestEntry records when an AssetTag was first emitted, so that repeated runs against a
+// growing spreadsheet can tell which rows are genuinely new.
+type manifestEntry struct {
+	AssetTag  string `json:"asset_tag"`
+	BoxName   string `json:"box_name"`
+	FirstSeen string `json:"first_seen"` // RFC3339
+}
+
+// manifest is the on-disk record of every AssetTag this tool has ever emitted for this
+// catalogue, keyed by AssetTag, so that -since can report only what's new.
+type manifest map[string]manifestEntry
+
+func loadManifest(path string) manifest {
+	m := make(manifest)
+	if path == "" {
+		return m
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m
+		}
+		log.Fatal("Unable to read manifest file "+path, err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		log.Fatal("Unable to parse manifest file "+path+" as JSON", err)
+	}
+	return m
+}
+
+func saveManifest(path string, m manifest) {
+	if path == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		log.Fatal("Unable to encode manifest", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Fatal("Unable to write manifest file "+path, err)
+	}
+}
+
+// applyManifest records any AssetTag in snipeData that isn't already in m (stamping it with
+// the current time as FirstSeen) and, if since is non-empty, drops every record whose
+// FirstSeen predates it so that only newly-seen rows are returned.
+func applyManifest(snipeData []SnipeITRecord, m manifest, since string) []SnipeITRecord {
+	var sinceTime time.Time
+	if since != "" {
+		var err error
+		sinceTime, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			log.Fatal("Unable to parse -since as RFC3339 timestamp", err)
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var result []SnipeITRecord
+	for _, data := range snipeData {
+		entry, known := m[data.AssetTag]
+		if !known {
+			entry = manifestEntry{AssetTag: data.AssetTag, BoxName: data.BoxName, FirstSeen: now}
+			m[data.AssetTag] = entry
+		}
+
+		if since != "" {
+			firstSeen, err := time.Parse(time.RFC3339, entry.FirstSeen)
+			if err != nil {
+				log.Fatal("Manifest entry for "+data.AssetTag+" has an invalid FirstSeen timestamp", err)
+			}
+			if firstSeen.Before(sinceTime) {
+				continue
+			}
+		}
+		result = append(result, data)
+	}
+	return result
+}
+
+// defaultManifestPath derives a manifest filename next to outputPath when none is given
+// explicitly, so that a sibling "<output>.manifest.json" is used by default.
+func defaultManifestPath(outputPath string) string {
+	return fmt.Sprintf("%s.manifest.json", outputPath)
+}
@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestComputeAssetTag(t *testing.T) {
+	entry := BoxCatRecord{BoxName: "BOX-1", Category: "Cables", Contents: "HDMI", Location: "Shelf A", ImportRef: "REF-42"}
+
+	t.Run("hash is deterministic", func(t *testing.T) {
+		first := computeAssetTag(entry, importRefHash)
+		second := computeAssetTag(entry, importRefHash)
+		if first != second {
+			t.Fatalf("computeAssetTag(%s) = %q, computeAssetTag(%s) = %q; want equal", importRefHash, first, importRefHash, second)
+		}
+	})
+
+	t.Run("hash changes with stable fields", func(t *testing.T) {
+		other := entry
+		other.Contents = "VGA"
+		if computeAssetTag(entry, importRefHash) == computeAssetTag(other, importRefHash) {
+			t.Fatal("computeAssetTag should differ when Contents differs")
+		}
+	})
+
+	t.Run("column uses ImportRef", func(t *testing.T) {
+		got := computeAssetTag(entry, importRefColumn)
+		want := "BOX-1-REF-42"
+		if got != want {
+			t.Fatalf("computeAssetTag(%s) = %q, want %q", importRefColumn, got, want)
+		}
+	})
+}
+
+func TestApplyManifest(t *testing.T) {
+	t.Run("stamps new entries and keeps them", func(t *testing.T) {
+		m := loadManifest("")
+		data := []SnipeITRecord{{AssetTag: "BOX-1-abc"}}
+
+		result := applyManifest(data, m, "")
+
+		if len(result) != 1 {
+			t.Fatalf("len(result) = %d, want 1", len(result))
+		}
+		if _, ok := m["BOX-1-abc"]; !ok {
+			t.Fatal("applyManifest did not record a manifest entry for a new AssetTag")
+		}
+	})
+
+	t.Run("since drops rows first seen before it", func(t *testing.T) {
+		m := manifest{
+			"OLD-1": {AssetTag: "OLD-1", FirstSeen: "2020-01-01T00:00:00Z"},
+			"NEW-1": {AssetTag: "NEW-1", FirstSeen: "2030-01-01T00:00:00Z"},
+		}
+		data := []SnipeITRecord{{AssetTag: "OLD-1"}, {AssetTag: "NEW-1"}}
+
+		result := applyManifest(data, m, "2025-01-01T00:00:00Z")
+
+		if len(result) != 1 || result[0].AssetTag != "NEW-1" {
+			t.Fatalf("applyManifest with -since did not drop the old row; got %+v", result)
+		}
+	})
+}
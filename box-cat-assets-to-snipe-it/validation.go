@@ -0,0 +1,161 @@
+package main
+
+// This file turns the ad-hoc fmt.Println warnings that processBoxCatContents/parseBoxCatRows
+// used to emit into a structured []ValidationIssue, so that the catalogue can be checked in
+// CI or a pre-commit hook against the master spreadsheet: -report writes the issues out for
+// another tool to consume, and -strict/-fail-on make the process exit non-zero when problems
+// are found instead of always exiting 0.
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Severity classifies how serious a ValidationIssue is.
+type Severity int
+
+const (
+	Info Severity = iota
+	Warn
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "Info"
+	case Warn:
+		return "Warn"
+	case Error:
+		return "Error"
+	default:
+		return "Unknown"
+	}
+}
+
+// parseSeverity converts a -fail-on flag value to a Severity.
+func parseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "info":
+		return Info, nil
+	case "warn", "warning":
+		return Warn, nil
+	case "error":
+		return Error, nil
+	default:
+		return 0, fmt.Errorf("unknown severity %q: expected info, warn or error", s)
+	}
+}
+
+// ValidationIssue records one thing noticed while parsing a catalogue row.
+type ValidationIssue struct {
+	Row      int
+	BoxName  string
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+// addIssue appends a ValidationIssue to issues and, as processBoxCatContents always did
+// before this existed, prints it so that a user running the tool interactively still sees it.
+func addIssue(issues *[]ValidationIssue, row int, boxName string, severity Severity, code, message string) {
+	*issues = append(*issues, ValidationIssue{Row: row, BoxName: boxName, Severity: severity, Code: code, Message: message})
+	fmt.Println(message)
+}
+
+// loadAllowlist reads a plain text file of one allowed value per line (blank lines and
+// lines starting with "#" are ignored) into a set. An empty path means "no allowlist", and
+// is represented as a nil map so that checks against it are always skipped.
+func loadAllowlist(path string) map[string]bool {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal("Unable to read allowlist file "+path, err)
+	}
+	defer f.Close()
+
+	allowlist := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		allowlist[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal("Unable to read allowlist file "+path, err)
+	}
+	return allowlist
+}
+
+// WriteValidationReport writes issues to path as JSON or CSV, chosen by path's extension
+// (".csv", otherwise JSON).
+func WriteValidationReport(path string, issues []ValidationIssue) error {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return writeValidationReportCSV(path, issues)
+	}
+	return writeValidationReportJSON(path, issues)
+}
+
+func writeValidationReportJSON(path string, issues []ValidationIssue) error {
+	type jsonIssue struct {
+		Row      int    `json:"row"`
+		BoxName  string `json:"box_name"`
+		Severity string `json:"severity"`
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+	}
+	out := make([]jsonIssue, len(issues))
+	for i, issue := range issues {
+		out[i] = jsonIssue{Row: issue.Row, BoxName: issue.BoxName, Severity: issue.Severity.String(), Code: issue.Code, Message: issue.Message}
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func writeValidationReportCSV(path string, issues []ValidationIssue) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	writer.Write([]string{"Row", "BoxName", "Severity", "Code", "Message"})
+	for _, issue := range issues {
+		writer.Write([]string{fmt.Sprint(issue.Row), issue.BoxName, issue.Severity.String(), issue.Code, issue.Message})
+	}
+	return writer.Error()
+}
+
+// noIssues is returned by worstSeverity when issues is empty. It is distinct from (and lower
+// than) Info so that callers comparing against a -fail-on threshold don't mistake "nothing to
+// report" for "the mildest possible issue was found".
+const noIssues Severity = -1
+
+// worstSeverity returns the most severe Severity present in issues, or noIssues if there are none.
+func worstSeverity(issues []ValidationIssue) Severity {
+	worst := noIssues
+	for _, issue := range issues {
+		if issue.Severity > worst {
+			worst = issue.Severity
+		}
+	}
+	return worst
+}
@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestParseSeverity(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Severity
+		wantErr bool
+	}{
+		{"info", Info, false},
+		{"Info", Info, false},
+		{"warn", Warn, false},
+		{"warning", Warn, false},
+		{"error", Error, false},
+		{"bogus", 0, true},
+	}
+	for _, c := range cases {
+		got, err := parseSeverity(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseSeverity(%q) = %v, nil; want error", c.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSeverity(%q) returned unexpected error: %v", c.in, err)
+		}
+		if got != c.want {
+			t.Errorf("parseSeverity(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestWorstSeverity(t *testing.T) {
+	if got := worstSeverity(nil); got >= Info {
+		t.Errorf("worstSeverity(nil) = %v, want a sentinel below Info", got)
+	}
+
+	issues := []ValidationIssue{{Severity: Info}}
+	if got := worstSeverity(issues); got != Info {
+		t.Errorf("worstSeverity(%v) = %v, want %v", issues, got, Info)
+	}
+
+	issues = []ValidationIssue{{Severity: Warn}, {Severity: Error}, {Severity: Info}}
+	if got := worstSeverity(issues); got != Error {
+		t.Errorf("worstSeverity(%v) = %v, want %v", issues, got, Error)
+	}
+}